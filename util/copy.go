@@ -0,0 +1,213 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ProgressReporter is notified as CopyTree copies each file. Implementations
+// should be cheap to call; a no-op ProgressReporter is used when
+// GlobalVar.Quiet is set.
+type ProgressReporter interface {
+	CopiedFile(path string)
+}
+
+type quietProgressReporter struct{}
+
+func (quietProgressReporter) CopiedFile(path string) {}
+
+// stdoutProgressReporter prints each copied file's path to stdout. It is
+// the default ProgressReporter used whenever GlobalVar.Quiet isn't set and
+// the caller didn't supply one of its own.
+type stdoutProgressReporter struct{}
+
+func (stdoutProgressReporter) CopiedFile(path string) {
+	fmt.Println(path)
+}
+
+// CopyOpts controls how CopyTree copies a tree.
+type CopyOpts struct {
+	// PreserveLinks recreates symlinks as symlinks instead of following
+	// them.
+	PreserveLinks bool
+	// Overwrite allows an existing destination file to be replaced.
+	// When false, CopyTree errors out instead of clobbering an existing
+	// destination file.
+	Overwrite bool
+	// DereferenceSymlinks copies the target of a symlink instead of the
+	// symlink itself. Takes precedence over PreserveLinks.
+	DereferenceSymlinks bool
+	// Progress, if set, is notified as each file is copied. Defaults to
+	// a no-op when GlobalVar.Quiet is set and Progress is nil.
+	Progress ProgressReporter
+}
+
+// CopyTree copies the files/dirs specified by srcGlob into destDir using a
+// pure-Go recursive walker, preserving permission bits, symlinks and hardlinks
+// instead of shelling out to cp.
+// It is assumed that destDir is present and writable.
+func CopyTree(srcGlob string, destDir string, opts CopyOpts, errPrefix ErrPrefix) error {
+	progress := opts.Progress
+	if progress == nil {
+		if GlobalVar.Quiet {
+			progress = quietProgressReporter{}
+		} else {
+			progress = stdoutProgressReporter{}
+		}
+	}
+
+	srcPaths, patternErr := filepath.Glob(srcGlob)
+	if patternErr != nil {
+		return fmt.Errorf("%sGlob %s returned %s", errPrefix, srcGlob, patternErr)
+	}
+
+	// inodeToDestPath tracks inode numbers of already-copied files so that
+	// hardlinks in the source tree are recreated as hardlinks in destDir
+	// instead of being duplicated.
+	inodeToDestPath := make(map[uint64]string)
+
+	for _, srcPath := range srcPaths {
+		destPath := filepath.Join(destDir, filepath.Base(srcPath))
+		info, err := os.Lstat(srcPath)
+		if err != nil {
+			return fmt.Errorf("%scopying %s to %s errored out with '%s'", errPrefix, srcPath, destPath, err)
+		}
+		if err := copyEntry(srcPath, destPath, info, opts, inodeToDestPath, progress); err != nil {
+			return fmt.Errorf("%scopying %s to %s errored out with '%s'",
+				errPrefix, srcPath, destPath, err)
+		}
+	}
+	return nil
+}
+
+// copyEntry copies the single file, symlink or directory at srcPath
+// (described by info, from an Lstat so symlinks are reported as such) to
+// destPath, recursing into directories itself rather than relying on
+// filepath.WalkDir, which never descends into a path that is itself a
+// symlink - something CopyTree must do when DereferenceSymlinks is set,
+// including for symlinked directories found mid-tree.
+func copyEntry(
+	srcPath string, destPath string, info os.FileInfo, opts CopyOpts,
+	inodeToDestPath map[uint64]string, progress ProgressReporter) error {
+
+	isSymlink := info.Mode()&os.ModeSymlink != 0
+	if isSymlink && opts.PreserveLinks && !opts.DereferenceSymlinks {
+		return copySymlink(srcPath, destPath, opts)
+	}
+	if isSymlink {
+		// Following the symlink: os.Open/os.ReadDir/os.Stat on srcPath
+		// transparently follow it, so all that's needed is to resolve
+		// what it points at and keep going with that as the real info.
+		derefInfo, err := os.Stat(srcPath)
+		if err != nil {
+			return err
+		}
+		info = derefInfo
+	}
+
+	if info.IsDir() {
+		if err := os.MkdirAll(destPath, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(srcPath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			entryInfo, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			childSrc := filepath.Join(srcPath, entry.Name())
+			childDest := filepath.Join(destPath, entry.Name())
+			if err := copyEntry(childSrc, childDest, entryInfo, opts, inodeToDestPath, progress); err != nil {
+				return err
+			}
+		}
+		// MkdirAll's mode is masked by the umask; set the source's bits.
+		return os.Chmod(destPath, info.Mode().Perm())
+	}
+
+	return copyFile(srcPath, destPath, info, opts, inodeToDestPath, progress)
+}
+
+func copySymlink(srcPath string, destPath string, opts CopyOpts) error {
+	if err := removeExistingDest(destPath, opts); err != nil {
+		return err
+	}
+	target, err := os.Readlink(srcPath)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(target, destPath)
+}
+
+func copyFile(
+	srcPath string, destPath string, info os.FileInfo, opts CopyOpts,
+	inodeToDestPath map[uint64]string, progress ProgressReporter) error {
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Nlink > 1 {
+		if existingDest, seen := inodeToDestPath[stat.Ino]; seen {
+			if err := removeExistingDest(destPath, opts); err != nil {
+				return err
+			}
+			if err := os.Link(existingDest, destPath); err == nil {
+				progress.CopiedFile(srcPath)
+				return nil
+			}
+			// Fall through to a regular copy if hardlinking across
+			// devices isn't possible.
+		} else {
+			inodeToDestPath[stat.Ino] = destPath
+		}
+	}
+
+	if err := removeExistingDest(destPath, opts); err != nil {
+		return err
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	if err := dst.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	progress.CopiedFile(srcPath)
+	return nil
+}
+
+// removeExistingDest removes a pre-existing destPath when opts.Overwrite
+// is set, so the subsequent create can assume destPath is absent. When
+// opts.Overwrite is false and destPath already exists, it returns an
+// error instead of letting the caller silently clobber it.
+func removeExistingDest(destPath string, opts CopyOpts) error {
+	if _, err := os.Lstat(destPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !opts.Overwrite {
+		return fmt.Errorf("%s already exists and Overwrite is false", destPath)
+	}
+	return os.Remove(destPath)
+}
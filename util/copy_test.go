@@ -0,0 +1,131 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyTreeDereferencesSymlinkedDirectory(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	realDir := filepath.Join(src, "real")
+	if err := os.Mkdir(realDir, 0775); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(realDir, "f.txt"), []byte("contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(realDir, filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CopyOpts{Overwrite: true, DereferenceSymlinks: true}
+	if err := CopyTree(filepath.Join(src, "link"), dest, opts, "test: "); err != nil {
+		t.Fatalf("CopyTree: %s", err)
+	}
+
+	copied := filepath.Join(dest, "link", "f.txt")
+	info, err := os.Lstat(copied)
+	if err != nil {
+		t.Fatalf("copied file missing: %s", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("%s was copied as a symlink, want a real directory/file", copied)
+	}
+	contents, err := os.ReadFile(copied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "contents" {
+		t.Errorf("copied file contents = %q, want %q", contents, "contents")
+	}
+}
+
+func TestCopyTreeOverwriteFalseRefusesToClobber(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(src, "f.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "f.txt"), []byte("orig"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CopyOpts{Overwrite: false}
+	err := CopyTree(filepath.Join(src, "f.txt"), dest, opts, "test: ")
+	if err == nil {
+		t.Fatal("expected an error copying onto an existing file with Overwrite: false")
+	}
+
+	contents, readErr := os.ReadFile(filepath.Join(dest, "f.txt"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if string(contents) != "orig" {
+		t.Errorf("destination file was modified: contents = %q, want %q", contents, "orig")
+	}
+}
+
+func TestCopyTreePreservesHardlinks(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	srcDir := filepath.Join(src, "d")
+	if err := os.Mkdir(srcDir, 0775); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("shared"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(srcDir, "a.txt"), filepath.Join(srcDir, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CopyOpts{Overwrite: true}
+	if err := CopyTree(srcDir, dest, opts, "test: "); err != nil {
+		t.Fatalf("CopyTree: %s", err)
+	}
+
+	aInfo, err := os.Stat(filepath.Join(dest, "d", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bInfo, err := os.Stat(filepath.Join(dest, "d", "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(aInfo, bInfo) {
+		t.Errorf("a.txt and b.txt were not recreated as hardlinks to the same inode")
+	}
+}
+
+func TestCopyTreePreservesSymlinks(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(src, "target.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", filepath.Join(src, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CopyOpts{PreserveLinks: true, Overwrite: true}
+	if err := CopyTree(filepath.Join(src, "link.txt"), dest, opts, "test: "); err != nil {
+		t.Fatalf("CopyTree: %s", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(dest, "link.txt"))
+	if err != nil {
+		t.Fatalf("copied file is not a symlink: %s", err)
+	}
+	if target != "target.txt" {
+		t.Errorf("symlink target = %q, want %q", target, "target.txt")
+	}
+}
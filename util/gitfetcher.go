@@ -0,0 +1,202 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// shaRevisionPattern matches revisions that look like a commit SHA
+// (full or abbreviated) rather than a branch or tag name.
+var shaRevisionPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// FetchOpts describes a single git fetch: what to clone, how much
+// history to fetch, and which paths (if any) to sparse-checkout.
+type FetchOpts struct {
+	URL           string
+	Revision      string
+	Depth         int
+	SparsePaths   []string
+	WorkDirPrefix string
+}
+
+// GitFetcher clones repos into unique directories under SrcDir so that
+// concurrent fetches of different sources never collide. Fetches with
+// identical FetchOpts share one directory; concurrent Clone calls for the
+// same FetchOpts are not serialized.
+type GitFetcher struct {
+	SrcDir string
+}
+
+// NewGitFetcher returns a GitFetcher rooted at the configured SrcDir.
+func NewGitFetcher() *GitFetcher {
+	return &GitFetcher{SrcDir: GetSrcDir()}
+}
+
+// Clone fetches opts.URL at opts.Revision into a unique directory under
+// SrcDir and returns a GitRepo pointing at it. workDir is derived
+// deterministically from opts (see uniqueWorkDir), so a repeat Clone call
+// for the same opts reuses the existing checkout instead of erroring out
+// on a non-empty destination.
+func (fetcher *GitFetcher) Clone(ctx context.Context, opts FetchOpts) (*GitRepo, error) {
+	workDir := uniqueWorkDir(fetcher.SrcDir, "eext-src", opts)
+	if _, err := os.Stat(filepath.Join(workDir, ".git")); err == nil {
+		return &GitRepo{Revision: opts.Revision, ClonedDir: workDir}, nil
+	}
+
+	if err := MaybeCreateDirWithParents(fetcher.SrcDir, ErrPrefix("GitFetcher: ")); err != nil {
+		return nil, err
+	}
+
+	cloneArgs, shaRevision := buildCloneArgs(opts, workDir)
+	if err := fetcher.runGitCmd(ctx, "", cloneArgs...); err != nil {
+		return nil, fmt.Errorf("GitFetcher: Error '%s' cloning %s into %s", err, opts.URL, workDir)
+	}
+
+	if opts.Depth > 0 && shaRevision {
+		fetchArgs := []string{"fetch", "--depth", strconv.Itoa(opts.Depth), "origin", opts.Revision}
+		if err := fetcher.runGitCmd(ctx, workDir, fetchArgs...); err != nil {
+			// Some servers reject fetching an arbitrary SHA outright; fall
+			// back to deepening the single branch already cloned, which
+			// works as long as that branch's history contains the SHA.
+			if unshallowErr := fetcher.runGitCmd(ctx, workDir, "fetch", "--unshallow", "origin"); unshallowErr != nil {
+				return nil, fmt.Errorf("GitFetcher: Error '%s' fetching %s in %s (deepening fallback also failed: %s)",
+					err, opts.Revision, workDir, unshallowErr)
+			}
+		}
+	}
+
+	if len(opts.SparsePaths) > 0 {
+		if err := fetcher.runGitCmd(ctx, workDir, "sparse-checkout", "init", "--cone"); err != nil {
+			return nil, fmt.Errorf("GitFetcher: Error '%s' initializing sparse-checkout in %s", err, workDir)
+		}
+		setArgs := append([]string{"sparse-checkout", "set"}, opts.SparsePaths...)
+		if err := fetcher.runGitCmd(ctx, workDir, setArgs...); err != nil {
+			return nil, fmt.Errorf("GitFetcher: Error '%s' setting sparse paths in %s", err, workDir)
+		}
+	}
+
+	checkoutRef := opts.Revision
+	if checkoutRef == "" {
+		checkoutRef = "HEAD"
+	}
+	if err := fetcher.runGitCmd(ctx, workDir, "checkout", checkoutRef); err != nil {
+		return nil, fmt.Errorf("GitFetcher: Error '%s' checking out %s in %s", err, checkoutRef, workDir)
+	}
+
+	return &GitRepo{Revision: opts.Revision, ClonedDir: workDir}, nil
+}
+
+// buildCloneArgs builds the "git clone" argument list for opts, cloning
+// into workDir. It also reports whether opts.Revision looks like a commit
+// SHA, since a SHA can't be passed to --branch and instead needs a
+// follow-up fetch of that exact commit (see Clone).
+func buildCloneArgs(opts FetchOpts, workDir string) (args []string, shaRevision bool) {
+	shaRevision = opts.Revision != "" && shaRevisionPattern.MatchString(opts.Revision)
+
+	args = []string{"clone"}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth), "--single-branch")
+		switch {
+		case shaRevision:
+			args = append(args, "--no-checkout")
+		case opts.Revision != "":
+			// --single-branch only fetches the tip of one branch, so
+			// without naming it explicitly a shallow clone can only ever
+			// check out the remote's default branch tip.
+			args = append(args, "--branch", opts.Revision)
+		}
+	}
+	if len(opts.SparsePaths) > 0 {
+		args = append(args, "--no-checkout", "--filter=blob:none")
+	}
+	args = append(args, opts.URL, workDir)
+	return args, shaRevision
+}
+
+// uniqueWorkDir derives a directory name for opts under srcDir from a
+// hash of its URL, revision and sparse paths, so that parallel fetches
+// that differ in any of these never collide; identical opts always map to
+// the same directory. defaultPrefix is used when opts.WorkDirPrefix is
+// unset.
+func uniqueWorkDir(srcDir string, defaultPrefix string, opts FetchOpts) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", opts.URL, opts.Revision, strings.Join(opts.SparsePaths, "\x00"))
+	shortHash := hex.EncodeToString(h.Sum(nil))[:12]
+
+	prefix := opts.WorkDirPrefix
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	return filepath.Join(srcDir, fmt.Sprintf("%s-%s", prefix, shortHash))
+}
+
+// runGitCmd runs a git subcommand, optionally inside dir, honoring ctx
+// cancellation.
+func (fetcher *GitFetcher) runGitCmd(ctx context.Context, dir string, arg ...string) error {
+	cmd := exec.CommandContext(ctx, "git", arg...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	if !GlobalVar.Quiet {
+		cmd.Stdout = os.Stdout
+	}
+	return cmd.Run()
+}
+
+// GetRepoDir returns the path of the cloned source repo.
+// If opts is non-nil, the repo is fetched via a GitFetcher and the
+// resulting directory is returned.
+// Else, if repo is specified, its subpath under the SrcDir config is
+// returned.
+// If neither is specified, we return the current working directory.
+func GetRepoDir(repo string, opts *FetchOpts) (string, error) {
+	if opts != nil {
+		gitSpec, err := NewGitFetcher().Clone(context.Background(), *opts)
+		if err != nil {
+			return "", err
+		}
+		return gitSpec.ClonedDir, nil
+	}
+
+	if repo != "" {
+		return filepath.Join(GetSrcDir(), repo), nil
+	}
+	return ".", nil
+}
+
+// GetSrcDir returns the configured SrcDir that cloned source repos live
+// under.
+func GetSrcDir() string {
+	return viper.GetString("SrcDir")
+}
+
+// checkOutputInDir runs name with arg in dir and returns its stdout, or
+// an error if it exits non-zero.
+func checkOutputInDir(dir string, name string, arg ...string) (string, error) {
+	cmd := exec.Command(name, arg...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return string(output),
+				fmt.Errorf("Running '%s %s' in %s: exited with exit-code %d\nstderr:\n%s",
+					name, strings.Join(arg, " "), dir, exitErr.ExitCode(), exitErr.Stderr)
+		}
+		return string(output),
+			fmt.Errorf("Running '%s %s' in %s failed with '%s'",
+				name, strings.Join(arg, " "), dir, err)
+	}
+	return string(output), nil
+}
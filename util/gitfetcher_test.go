@@ -0,0 +1,132 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestBuildCloneArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     FetchOpts
+		wantArgs []string
+		wantSHA  bool
+	}{
+		{
+			name:     "no revision",
+			opts:     FetchOpts{URL: "https://example.com/repo.git"},
+			wantArgs: []string{"clone", "https://example.com/repo.git", "/work"},
+			wantSHA:  false,
+		},
+		{
+			name:     "branch revision, no depth",
+			opts:     FetchOpts{URL: "https://example.com/repo.git", Revision: "main"},
+			wantArgs: []string{"clone", "https://example.com/repo.git", "/work"},
+			wantSHA:  false,
+		},
+		{
+			name:     "branch revision, shallow",
+			opts:     FetchOpts{URL: "https://example.com/repo.git", Revision: "main", Depth: 1},
+			wantArgs: []string{"clone", "--depth", "1", "--single-branch", "--branch", "main", "https://example.com/repo.git", "/work"},
+			wantSHA:  false,
+		},
+		{
+			name:     "SHA revision, shallow",
+			opts:     FetchOpts{URL: "https://example.com/repo.git", Revision: "abc1234", Depth: 1},
+			wantArgs: []string{"clone", "--depth", "1", "--single-branch", "--no-checkout", "https://example.com/repo.git", "/work"},
+			wantSHA:  true,
+		},
+		{
+			name:     "SHA revision, no depth",
+			opts:     FetchOpts{URL: "https://example.com/repo.git", Revision: "abc1234"},
+			wantArgs: []string{"clone", "https://example.com/repo.git", "/work"},
+			wantSHA:  true,
+		},
+		{
+			name:     "sparse paths",
+			opts:     FetchOpts{URL: "https://example.com/repo.git", SparsePaths: []string{"a", "b"}},
+			wantArgs: []string{"clone", "--no-checkout", "--filter=blob:none", "https://example.com/repo.git", "/work"},
+			wantSHA:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			args, shaRevision := buildCloneArgs(tc.opts, "/work")
+			if strings.Join(args, " ") != strings.Join(tc.wantArgs, " ") {
+				t.Errorf("buildCloneArgs(%+v) args = %v, want %v", tc.opts, args, tc.wantArgs)
+			}
+			if shaRevision != tc.wantSHA {
+				t.Errorf("buildCloneArgs(%+v) shaRevision = %v, want %v", tc.opts, shaRevision, tc.wantSHA)
+			}
+		})
+	}
+}
+
+// initTestRepo creates a local git repo under dir with a two-commit
+// history and returns the hash of the HEAD commit.
+func initTestRepo(t *testing.T, dir string) string {
+	t.Helper()
+	run := func(arg ...string) {
+		cmd := exec.Command("git", arg...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", arg, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	if err := os.WriteFile(dir+"/f.txt", []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "-A")
+	run("commit", "-q", "-m", "one")
+	if err := os.WriteFile(dir+"/f.txt", []byte("one\ntwo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "-A")
+	run("commit", "-q", "-m", "two")
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestGitFetcherCloneReusesExistingWorkDir(t *testing.T) {
+	upstream := t.TempDir()
+	head := initTestRepo(t, upstream)
+
+	fetcher := &GitFetcher{SrcDir: t.TempDir()}
+	opts := FetchOpts{URL: upstream, Revision: head, Depth: 1}
+
+	first, err := fetcher.Clone(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("first Clone: %s", err)
+	}
+
+	second, err := fetcher.Clone(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("second Clone (expected reuse): %s", err)
+	}
+	if second.ClonedDir != first.ClonedDir {
+		t.Errorf("second Clone used a different dir: %s != %s", second.ClonedDir, first.ClonedDir)
+	}
+
+	repo := &GitRepo{Revision: head, ClonedDir: first.ClonedDir}
+	current, err := repo.CurrentRevision()
+	if err != nil {
+		t.Fatalf("CurrentRevision: %s", err)
+	}
+	if current != head {
+		t.Errorf("checked out revision = %s, want %s", current, head)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// KeyRing is a set of armored public keys loaded once and cached in
+// memory, so it can be reused across many signature verifications in a
+// single eext invocation instead of re-importing keys into a fresh gpg
+// homedir for every call.
+type KeyRing struct {
+	entities openpgp.EntityList
+}
+
+// NewKeyRing loads the armored public keys at pubKeyPaths into a KeyRing.
+func NewKeyRing(pubKeyPaths ...string) (*KeyRing, error) {
+	keyRing := &KeyRing{}
+	for _, pubKeyPath := range pubKeyPaths {
+		keyFile, err := os.Open(pubKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error '%s' opening public key %s", err, pubKeyPath)
+		}
+
+		entities, err := openpgp.ReadArmoredKeyRing(keyFile)
+		keyFile.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error '%s' reading public key %s", err, pubKeyPath)
+		}
+		keyRing.entities = append(keyRing.entities, entities...)
+	}
+	return keyRing, nil
+}
+
+// VerifyDetachedSignature checks sig as a detached signature of data
+// against the keys in the keyring.
+func (keyRing *KeyRing) VerifyDetachedSignature(data io.Reader, sig io.Reader) error {
+	if _, err := openpgp.CheckDetachedSignature(keyRing.entities, data, sig, nil); err != nil {
+		return fmt.Errorf("Error '%s' verifying detached signature", err)
+	}
+	return nil
+}
+
+// VerifyGitCommit checks that commit carries a signature from a key in
+// the keyring.
+func (keyRing *KeyRing) VerifyGitCommit(commit *object.Commit) error {
+	if commit.PGPSignature == "" {
+		return fmt.Errorf("commit %s is not signed", commit.Hash)
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return fmt.Errorf("Error '%s' encoding commit %s for verification", err, commit.Hash)
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return fmt.Errorf("Error '%s' reading encoded commit %s", err, commit.Hash)
+	}
+
+	return keyRing.VerifyDetachedSignature(reader, strings.NewReader(commit.PGPSignature))
+}
+
+// VerifyGitTag checks that tag carries a signature from a key in the
+// keyring.
+func (keyRing *KeyRing) VerifyGitTag(tag *object.Tag) error {
+	if tag.PGPSignature == "" {
+		return fmt.Errorf("tag %s is not signed", tag.Hash)
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := tag.EncodeWithoutSignature(encoded); err != nil {
+		return fmt.Errorf("Error '%s' encoding tag %s for verification", err, tag.Hash)
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return fmt.Errorf("Error '%s' reading encoded tag %s", err, tag.Hash)
+	}
+
+	return keyRing.VerifyDetachedSignature(reader, strings.NewReader(tag.PGPSignature))
+}
+
+// keyRingCache caches KeyRings by their pubKeyPath so a single eext
+// invocation that verifies many packages against the same public key
+// only parses that key once.
+var keyRingCache sync.Map // string -> *KeyRing
+
+// loadKeyRing returns the cached KeyRing for pubKeyPath, loading and
+// caching it on first use.
+func loadKeyRing(pubKeyPath string) (*KeyRing, error) {
+	if cached, ok := keyRingCache.Load(pubKeyPath); ok {
+		return cached.(*KeyRing), nil
+	}
+
+	keyRing, err := NewKeyRing(pubKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if actual, loaded := keyRingCache.LoadOrStore(pubKeyPath, keyRing); loaded {
+		return actual.(*KeyRing), nil
+	}
+	return keyRing, nil
+}
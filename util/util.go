@@ -8,10 +8,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
-	"github.com/spf13/viper"
 	"golang.org/x/sys/unix"
 )
 
@@ -26,26 +24,12 @@ var GlobalVar Globals
 // ErrPrefix is a container type for error prefix strings.
 type ErrPrefix string
 
-type GitSpec struct {
-	Revision  string
-	ClonedDir string
-}
-
-// Returns if the provided revision is a "COMMIT" or a "TAG"
-func (spec *GitSpec) typeOfGitRevision() string {
-	// Check 1st line of git show
-	return ""
-}
-
-// Returns a unique version number based on the commit/tag
-func (spec *GitSpec) GetVersionFromRevision() string {
-	// If type is TAG, return as is
-
-	// If type is commit
-	// If short commit, return as is
-
-	// If long commit, reduce size
-	return ""
+// GetVersionFromRevision returns a unique version number for repo's
+// configured revision, dispatching to whichever SourceRepo backend repo
+// is (see vcs.go).
+func GetVersionFromRevision(repo SourceRepo) (string, error) {
+	_, id, err := repo.ResolveRevision()
+	return id, err
 }
 
 // RunSystemCmd runs a command on the shell and pipes to stdout and stderr
@@ -124,7 +108,7 @@ func MaybeCreateDir(dirPath string, errPrefix ErrPrefix) error {
 // MaybeCreateDirWithParents creates a directory at dirPath if one
 // doesn't already exist. It also creates any parent directories.
 func MaybeCreateDirWithParents(dirPath string, errPrefix ErrPrefix) error {
-	if err := RunSystemCmd("mkdir", "-p", dirPath); err != nil {
+	if err := os.MkdirAll(dirPath, 0775); err != nil {
 		return fmt.Errorf("%sError '%s' trying to create directory %s with parents",
 			errPrefix, err, dirPath)
 	}
@@ -144,6 +128,9 @@ func RemoveDirs(dirs []string, errPrefix ErrPrefix) error {
 
 // CopyToDestDir copies files/dirs specified by srcGlob to destDir
 // It is assumed that destDir is present and writable
+//
+// Deprecated: CopyToDestDir is a thin compatibility shim over CopyTree.
+// Prefer calling CopyTree directly for new code.
 func CopyToDestDir(
 	srcGlob string,
 	destDir string,
@@ -154,34 +141,7 @@ func CopyToDestDir(
 			errPrefix, destDir, err)
 	}
 
-	filesToCopy, patternErr := filepath.Glob(srcGlob)
-	if patternErr != nil {
-		return fmt.Errorf("%sGlob %s returned %s", errPrefix, srcGlob, patternErr)
-	}
-
-	for _, file := range filesToCopy {
-		insideDestDir := destDir + "/"
-		if err := RunSystemCmd("cp", "-rf", file, insideDestDir); err != nil {
-			return fmt.Errorf("%scopying %s to %s errored out with '%s'",
-				errPrefix, file, insideDestDir, err)
-		}
-	}
-	return nil
-}
-
-// GetRepoDir returns the path of the cloned source repo.
-// If repo is specified, it's subpath under SrcDir config is
-// returned.
-// If no repo is specfied, we return current working directory.
-func GetRepoDir(repo string) string {
-	var repoDir string
-	if repo != "" {
-		srcDir := viper.GetString("SrcDir")
-		repoDir = filepath.Join(srcDir, repo)
-	} else {
-		repoDir = "."
-	}
-	return repoDir
+	return CopyTree(srcGlob, destDir, CopyOpts{PreserveLinks: true, Overwrite: true}, errPrefix)
 }
 
 // VerifyRpmSignature verifies that the RPM specified at rpmPath
@@ -204,87 +164,43 @@ func VerifyRpmSignature(rpmPath string, errPrefix ErrPrefix) error {
 func VerifyTarballSignature(
 	tarballPath string, tarballSigPath string, pubKeyPath string,
 	errPrefix ErrPrefix) error {
-	tmpDir, mkdtErr := os.MkdirTemp("", "eext-keyring")
-	if mkdtErr != nil {
-		return fmt.Errorf("%sError '%s'creating temp dir for keyring",
-			errPrefix, mkdtErr)
+	keyRing, err := loadKeyRing(pubKeyPath)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' loading public-key %s", errPrefix, err, pubKeyPath)
 	}
-	defer os.RemoveAll(tmpDir)
-
-	keyRingPath := filepath.Join(tmpDir, "eext.gpg")
-	baseArgs := []string{
-		"--homedir", tmpDir,
-		"--no-default-keyring", "--keyring", keyRingPath}
-	gpgCmd := "gpg"
 
-	// Create keyring
-	createKeyRingCmdArgs := append(baseArgs, "--fingerprint")
-	if err := RunSystemCmd(gpgCmd, createKeyRingCmdArgs...); err != nil {
-		return fmt.Errorf("%sError '%s'creating keyring",
-			errPrefix, err)
+	tarball, err := os.Open(tarballPath)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' opening tarball %s", errPrefix, err, tarballPath)
 	}
+	defer tarball.Close()
 
-	// Import public key
-	importKeyCmdArgs := append(baseArgs, "--import", pubKeyPath)
-	if err := RunSystemCmd(gpgCmd, importKeyCmdArgs...); err != nil {
-		return fmt.Errorf("%sError '%s' importing public-key %s",
-			errPrefix, err, pubKeyPath)
+	tarballSig, err := os.Open(tarballSigPath)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' opening tarball signature %s", errPrefix, err, tarballSigPath)
 	}
+	defer tarballSig.Close()
 
-	verifySigArgs := append(baseArgs, "--verify", tarballSigPath, tarballPath)
-	if output, err := CheckOutput(gpgCmd, verifySigArgs...); err != nil {
-		return fmt.Errorf("%sError verifying signature %s for tarball %s with pubkey %s."+
-			"\ngpg --verify err: %sstdout:%s",
-			errPrefix, tarballSigPath, tarballPath, pubKeyPath, err, output)
+	if err := keyRing.VerifyDetachedSignature(tarball, tarballSig); err != nil {
+		return fmt.Errorf("%sError verifying signature %s for tarball %s with pubkey %s: %s",
+			errPrefix, tarballSigPath, tarballPath, pubKeyPath, err)
 	}
 
 	return nil
 }
 
-// VerifyGitSignature verifies that the git repo commit/tag is signed.
-func VerifyGitSignature(pubKeyPath string, gitSpec GitSpec, errPrefix ErrPrefix) error {
-	tmpDir, mkdtErr := os.MkdirTemp("", "eext-keyring")
-	if mkdtErr != nil {
-		return fmt.Errorf("%sError '%s'creating temp dir for keyring",
-			errPrefix, mkdtErr)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	keyRingPath := filepath.Join(tmpDir, "eext.gpg")
-	baseArgs := []string{
-		"--homedir", tmpDir,
-		"--no-default-keyring", "--keyring", keyRingPath}
-	gpgCmd := "gpg"
-
-	// Create keyring
-	createKeyRingCmdArgs := append(baseArgs, "--fingerprint")
-	if err := RunSystemCmd(gpgCmd, createKeyRingCmdArgs...); err != nil {
-		return fmt.Errorf("%sError '%s'creating keyring",
-			errPrefix, err)
-	}
-
-	// Import public key
-	importKeyCmdArgs := append(baseArgs, "--import", pubKeyPath)
-	if err := RunSystemCmd(gpgCmd, importKeyCmdArgs...); err != nil {
-		return fmt.Errorf("%sError '%s' importing public-key %s",
-			errPrefix, err, pubKeyPath)
-	}
-
-	var verifyRepoCmd []string
-	revision := gitSpec.Revision
-	revisionType := gitSpec.typeOfGitRevision()
-	if revisionType == "COMMIT" {
-		verifyRepoCmd = []string{"verify-commit", "-v", revision}
-	} else if revisionType == "TAG" {
-		verifyRepoCmd = []string{"verify-tag", "-v", revision}
-	} else {
-		return fmt.Errorf("%sinvalid revision %s provided, provide either a COMMIT or TAG", errPrefix, revision)
-	}
-	clonedDir := gitSpec.ClonedDir
-	err := RunSystemCmdInDir(clonedDir, "git", verifyRepoCmd...)
+// VerifyGitSignature verifies that repo's currently resolved revision is
+// signed with a valid key at pubKeyPath. It is a thin dispatcher over
+// SourceRepo.VerifySignature so the same entry point works for git,
+// mercurial, and tarball sources (see vcs.go).
+func VerifyGitSignature(pubKeyPath string, repo SourceRepo, errPrefix ErrPrefix) error {
+	keyRing, err := loadKeyRing(pubKeyPath)
 	if err != nil {
-		return fmt.Errorf("%serror during verifying git repo at %s: %s", errPrefix, clonedDir, err)
+		return fmt.Errorf("%sError '%s' loading public-key %s", errPrefix, err, pubKeyPath)
 	}
 
+	if err := repo.VerifySignature(keyRing); err != nil {
+		return fmt.Errorf("%s%s", errPrefix, err)
+	}
 	return nil
 }
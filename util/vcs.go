@@ -0,0 +1,55 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"context"
+	"fmt"
+)
+
+// VCSKind identifies which upstream VCS backend a manifest's source
+// lives in. It defaults to VCSGit so manifests written before pluggable
+// VCS backends existed keep working unchanged.
+type VCSKind string
+
+const (
+	VCSGit     VCSKind = "git"
+	VCSHg      VCSKind = "hg"
+	VCSTarball VCSKind = "tarball"
+)
+
+// SourceRepo is the common interface satisfied by every upstream VCS
+// backend eext knows how to fetch, version and verify. GitRepo, HgRepo
+// and TarballRepo are the concrete implementations.
+type SourceRepo interface {
+	// Clone fetches the repo per opts into its working directory.
+	Clone(ctx context.Context, opts FetchOpts) error
+	// CurrentRevision returns the revision currently checked out.
+	CurrentRevision() (string, error)
+	// ResolveRevision classifies the repo's configured revision,
+	// returning its kind ("COMMIT", "TAG", "UNKNOWN", or a
+	// backend-specific equivalent) and a stable version id derived
+	// from it.
+	ResolveRevision() (kind string, id string, err error)
+	// VerifySignature verifies that the resolved revision is signed by
+	// a key in keyRing.
+	VerifySignature(keyRing *KeyRing) error
+}
+
+// NewSourceRepo returns the SourceRepo implementation for kind, rooted at
+// clonedDir and tracking revision (VCSTarball ignores revision). kind
+// defaults to VCSGit when empty, so manifests that don't set a vcs field
+// keep resolving to git.
+func NewSourceRepo(kind VCSKind, revision string, clonedDir string) (SourceRepo, error) {
+	switch kind {
+	case "", VCSGit:
+		return &GitRepo{Revision: revision, ClonedDir: clonedDir}, nil
+	case VCSHg:
+		return &HgRepo{Revision: revision, ClonedDir: clonedDir}, nil
+	case VCSTarball:
+		return &TarballRepo{ClonedDir: clonedDir}, nil
+	default:
+		return nil, fmt.Errorf("unsupported vcs kind %q", kind)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// GitRepo is the git-backed SourceRepo implementation. It used to be
+// called GitSpec before pluggable VCS backends made it one of several
+// SourceRepo implementations (see vcs.go).
+type GitRepo struct {
+	Revision  string
+	ClonedDir string
+	// FullHash, if set, makes ResolveRevision return the full commit
+	// SHA instead of the usual 7-character short hash.
+	FullHash bool
+}
+
+// Clone fetches the repo per opts via a GitFetcher and adopts the
+// resulting clone directory.
+func (spec *GitRepo) Clone(ctx context.Context, opts FetchOpts) error {
+	gitSpec, err := NewGitFetcher().Clone(ctx, opts)
+	if err != nil {
+		return err
+	}
+	spec.Revision = gitSpec.Revision
+	spec.ClonedDir = gitSpec.ClonedDir
+	return nil
+}
+
+// CurrentRevision returns the commit currently checked out in ClonedDir.
+func (spec *GitRepo) CurrentRevision() (string, error) {
+	repo, err := git.PlainOpen(spec.ClonedDir)
+	if err != nil {
+		return "", fmt.Errorf("Error '%s' opening git repo at %s", err, spec.ClonedDir)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("Error '%s' reading HEAD of %s", err, spec.ClonedDir)
+	}
+	return head.Hash().String(), nil
+}
+
+// typeOfGitRevision returns "TAG" if spec.Revision itself names a tag,
+// "COMMIT" if it resolves to a commit, and "UNKNOWN" otherwise.
+func (spec *GitRepo) typeOfGitRevision() string {
+	repo, err := git.PlainOpen(spec.ClonedDir)
+	if err != nil {
+		return "UNKNOWN"
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(spec.Revision))
+	if err != nil {
+		return "UNKNOWN"
+	}
+
+	// A revision is a TAG only when it names a tag reference itself, not
+	// merely when some unrelated tag happens to point at the same
+	// commit (e.g. a commit SHA that is also the tip of a release tag).
+	if _, err := repo.Tag(spec.Revision); err == nil {
+		return "TAG"
+	}
+
+	if _, err := repo.CommitObject(*hash); err == nil {
+		return "COMMIT"
+	}
+
+	return "UNKNOWN"
+}
+
+// ResolveRevision classifies spec.Revision and returns its kind ("TAG",
+// "COMMIT" or "UNKNOWN") along with a stable version id: the tag name
+// verbatim, or the commit hash (short, unless FullHash is set).
+func (spec *GitRepo) ResolveRevision() (kind string, id string, err error) {
+	kind = spec.typeOfGitRevision()
+	if kind == "TAG" {
+		return kind, spec.Revision, nil
+	}
+
+	if kind == "COMMIT" {
+		repo, err := git.PlainOpen(spec.ClonedDir)
+		if err != nil {
+			return kind, "", fmt.Errorf("Error '%s' opening git repo at %s", err, spec.ClonedDir)
+		}
+		hash, err := repo.ResolveRevision(plumbing.Revision(spec.Revision))
+		if err != nil {
+			return kind, "", fmt.Errorf("Error '%s' resolving revision %s", err, spec.Revision)
+		}
+		fullHash := hash.String()
+		if spec.FullHash {
+			return kind, fullHash, nil
+		}
+		return kind, fullHash[:7], nil
+	}
+
+	return kind, "", fmt.Errorf("invalid revision %s provided, provide either a COMMIT or TAG", spec.Revision)
+}
+
+// VerifySignature verifies that spec.Revision is signed by a key in
+// keyRing.
+func (spec *GitRepo) VerifySignature(keyRing *KeyRing) error {
+	repo, err := git.PlainOpen(spec.ClonedDir)
+	if err != nil {
+		return fmt.Errorf("Error '%s' opening git repo at %s", err, spec.ClonedDir)
+	}
+
+	kind := spec.typeOfGitRevision()
+	switch kind {
+	case "COMMIT":
+		hash, err := repo.ResolveRevision(plumbing.Revision(spec.Revision))
+		if err != nil {
+			return fmt.Errorf("Error '%s' resolving revision %s", err, spec.Revision)
+		}
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return fmt.Errorf("Error '%s' reading commit %s", err, spec.Revision)
+		}
+		if err := keyRing.VerifyGitCommit(commit); err != nil {
+			return fmt.Errorf("error during verifying git repo at %s: %s", spec.ClonedDir, err)
+		}
+	case "TAG":
+		ref, err := repo.Tag(spec.Revision)
+		if err != nil {
+			return fmt.Errorf("Error '%s' resolving tag %s", err, spec.Revision)
+		}
+		tag, tagErr := repo.TagObject(ref.Hash())
+		if tagErr == nil {
+			if err := keyRing.VerifyGitTag(tag); err != nil {
+				return fmt.Errorf("error during verifying git repo at %s: %s", spec.ClonedDir, err)
+			}
+			break
+		}
+		if tagErr != plumbing.ErrObjectNotFound {
+			return fmt.Errorf("Error '%s' reading tag %s", tagErr, spec.Revision)
+		}
+		// A lightweight tag ref points directly at a commit rather than
+		// at a tag object, so there's no tag signature to check -
+		// verify the commit it points at instead.
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("Error '%s' reading commit %s for lightweight tag %s",
+				err, ref.Hash(), spec.Revision)
+		}
+		if err := keyRing.VerifyGitCommit(commit); err != nil {
+			return fmt.Errorf("error during verifying git repo at %s: %s", spec.ClonedDir, err)
+		}
+	default:
+		return fmt.Errorf("invalid revision %s provided, provide either a COMMIT or TAG", spec.Revision)
+	}
+
+	return nil
+}
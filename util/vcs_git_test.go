@@ -0,0 +1,101 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// newTestGitRepo creates a repo with two commits, an annotated tag on the
+// first commit, and a lightweight tag on HEAD, returning their hashes.
+func newTestGitRepo(t *testing.T) (dir string, firstCommit string, headCommit string) {
+	t.Helper()
+	dir = t.TempDir()
+	headCommit = initTestRepo(t, dir)
+
+	run := func(arg ...string) {
+		cmd := exec.Command("git", arg...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", arg, err, out)
+		}
+	}
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD~1").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstCommit = trimNewline(string(out))
+
+	run("tag", "-a", "v1", "-m", "release", firstCommit)
+	run("tag", "lightweight-tag", "HEAD")
+
+	return dir, firstCommit, headCommit
+}
+
+func trimNewline(s string) string {
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func TestResolveRevisionClassifiesCommitsAndTags(t *testing.T) {
+	dir, firstCommit, headCommit := newTestGitRepo(t)
+
+	tests := []struct {
+		name     string
+		revision string
+		wantKind string
+		wantID   string
+	}{
+		{name: "commit", revision: headCommit, wantKind: "COMMIT", wantID: headCommit[:7]},
+		{name: "annotated tag", revision: "v1", wantKind: "TAG", wantID: "v1"},
+		{name: "lightweight tag", revision: "lightweight-tag", wantKind: "TAG", wantID: "lightweight-tag"},
+		{name: "unknown", revision: "does-not-exist", wantKind: "UNKNOWN", wantID: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &GitRepo{Revision: tc.revision, ClonedDir: dir}
+			kind, id, err := spec.ResolveRevision()
+			if kind != tc.wantKind {
+				t.Errorf("kind = %s, want %s", kind, tc.wantKind)
+			}
+			if tc.wantKind == "UNKNOWN" {
+				if err == nil {
+					t.Errorf("expected an error for unknown revision, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if id != tc.wantID {
+				t.Errorf("id = %s, want %s", id, tc.wantID)
+			}
+		})
+	}
+
+	_ = firstCommit
+}
+
+func TestVerifySignatureLightweightTagChecksCommit(t *testing.T) {
+	dir, _, headCommit := newTestGitRepo(t)
+
+	spec := &GitRepo{Revision: "lightweight-tag", ClonedDir: dir}
+	keyRing := &KeyRing{}
+	err := spec.VerifySignature(keyRing)
+	if err == nil {
+		t.Fatal("expected an error verifying an unsigned commit, got nil")
+	}
+	// The error should come from checking the commit's signature, not from
+	// failing to find a tag object for the lightweight tag.
+	wantSubstr := "commit " + headCommit + " is not signed"
+	if got := err.Error(); !strings.Contains(got, wantSubstr) {
+		t.Errorf("VerifySignature error = %q, want it to contain %q", got, wantSubstr)
+	}
+}
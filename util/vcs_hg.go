@@ -0,0 +1,76 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HgRepo is the mercurial-backed SourceRepo implementation. Mercurial has
+// no widely-used pure-Go client, so HgRepo shells out to the hg binary
+// the same way the rest of eext shells out to external tools it doesn't
+// have a native client for.
+type HgRepo struct {
+	Revision  string
+	ClonedDir string
+}
+
+// Clone clones opts.URL at opts.Revision into a unique directory under
+// the configured SrcDir.
+func (repo *HgRepo) Clone(ctx context.Context, opts FetchOpts) error {
+	srcDir := GetSrcDir()
+	if err := MaybeCreateDirWithParents(srcDir, ErrPrefix("HgRepo: ")); err != nil {
+		return err
+	}
+
+	workDir := uniqueWorkDir(srcDir, "eext-hg-src", opts)
+	cloneArgs := []string{"clone"}
+	if opts.Revision != "" {
+		cloneArgs = append(cloneArgs, "-u", opts.Revision)
+	}
+	cloneArgs = append(cloneArgs, opts.URL, workDir)
+	if err := RunSystemCmd("hg", cloneArgs...); err != nil {
+		return fmt.Errorf("HgRepo: Error '%s' cloning %s into %s", err, opts.URL, workDir)
+	}
+
+	repo.Revision = opts.Revision
+	repo.ClonedDir = workDir
+	return nil
+}
+
+// CurrentRevision returns the changeset id currently checked out.
+func (repo *HgRepo) CurrentRevision() (string, error) {
+	output, err := checkOutputInDir(repo.ClonedDir, "hg", "id", "-i")
+	if err != nil {
+		return "", fmt.Errorf("Error '%s' reading current revision of %s", err, repo.ClonedDir)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ResolveRevision classifies repo.Revision as a "TAG" or "COMMIT" (hg
+// calls the latter a changeset) and returns its canonical id.
+func (repo *HgRepo) ResolveRevision() (kind string, id string, err error) {
+	tagsOutput, err := checkOutputInDir(repo.ClonedDir, "hg", "log", "-r", repo.Revision, "--template", "{tags}")
+	if err != nil {
+		return "", "", fmt.Errorf("Error '%s' resolving revision %s", err, repo.Revision)
+	}
+	if strings.TrimSpace(tagsOutput) != "" && strings.TrimSpace(tagsOutput) != "tip" {
+		return "TAG", repo.Revision, nil
+	}
+
+	idOutput, err := checkOutputInDir(repo.ClonedDir, "hg", "log", "-r", repo.Revision, "--template", "{node}")
+	if err != nil {
+		return "", "", fmt.Errorf("Error '%s' resolving revision %s", err, repo.Revision)
+	}
+	return "COMMIT", idOutput[:12], nil
+}
+
+// VerifySignature is currently unsupported for mercurial repos: hg has no
+// standard equivalent of git's signed commits/tags, so this always
+// errors rather than silently skipping verification.
+func (repo *HgRepo) VerifySignature(keyRing *KeyRing) error {
+	return fmt.Errorf("signature verification is not supported for mercurial sources")
+}
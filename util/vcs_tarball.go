@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// TarballRepo is the SourceRepo implementation for upstreams that are
+// published as a plain tarball (plus, usually, a detached signature)
+// rather than a VCS checkout. Its "revision" is the tarball's file name,
+// and its "clone" is just downloading that tarball (and optionally its
+// signature) into ClonedDir.
+type TarballRepo struct {
+	ClonedDir string
+	// TarballName is the file name the tarball is saved as under
+	// ClonedDir. Defaults to the URL's base name.
+	TarballName string
+	// SigName is the file name the detached signature is saved as
+	// under ClonedDir, if Clone is given a signature URL to fetch.
+	SigName string
+}
+
+// Clone downloads opts.URL (and, if set via opts.Revision, a detached
+// signature at that URL) into a unique directory under the configured
+// SrcDir.
+func (repo *TarballRepo) Clone(ctx context.Context, opts FetchOpts) error {
+	srcDir := GetSrcDir()
+	if err := MaybeCreateDirWithParents(srcDir, ErrPrefix("TarballRepo: ")); err != nil {
+		return err
+	}
+
+	workDir := uniqueWorkDir(srcDir, "eext-tarball-src", opts)
+	if err := MaybeCreateDirWithParents(workDir, ErrPrefix("TarballRepo: ")); err != nil {
+		return err
+	}
+
+	repo.TarballName = filepath.Base(opts.URL)
+	if err := downloadFile(ctx, opts.URL, filepath.Join(workDir, repo.TarballName)); err != nil {
+		return fmt.Errorf("TarballRepo: Error '%s' downloading %s", err, opts.URL)
+	}
+
+	if opts.Revision != "" {
+		repo.SigName = filepath.Base(opts.Revision)
+		if err := downloadFile(ctx, opts.Revision, filepath.Join(workDir, repo.SigName)); err != nil {
+			return fmt.Errorf("TarballRepo: Error '%s' downloading signature %s", err, opts.Revision)
+		}
+	}
+
+	repo.ClonedDir = workDir
+	return nil
+}
+
+// CurrentRevision returns the tarball's file name, which is the closest
+// thing a tarball source has to a revision.
+func (repo *TarballRepo) CurrentRevision() (string, error) {
+	return repo.TarballName, nil
+}
+
+// ResolveRevision always reports kind "TARBALL", with the tarball's file
+// name as its id.
+func (repo *TarballRepo) ResolveRevision() (kind string, id string, err error) {
+	if repo.TarballName == "" {
+		return "", "", fmt.Errorf("TarballRepo: no tarball has been cloned into %s", repo.ClonedDir)
+	}
+	return "TARBALL", repo.TarballName, nil
+}
+
+// VerifySignature verifies the tarball's detached signature, which must
+// already have been downloaded by Clone.
+func (repo *TarballRepo) VerifySignature(keyRing *KeyRing) error {
+	if repo.SigName == "" {
+		return fmt.Errorf("no signature was downloaded for tarball %s", repo.TarballName)
+	}
+
+	tarball, err := os.Open(filepath.Join(repo.ClonedDir, repo.TarballName))
+	if err != nil {
+		return fmt.Errorf("Error '%s' opening tarball %s", err, repo.TarballName)
+	}
+	defer tarball.Close()
+
+	sig, err := os.Open(filepath.Join(repo.ClonedDir, repo.SigName))
+	if err != nil {
+		return fmt.Errorf("Error '%s' opening tarball signature %s", err, repo.SigName)
+	}
+	defer sig.Close()
+
+	return keyRing.VerifyDetachedSignature(tarball, sig)
+}
+
+// downloadFile fetches url and saves it at destPath.
+func downloadFile(ctx context.Context, url string, destPath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %s", url, resp.Status)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}